@@ -0,0 +1,438 @@
+package godebug
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// breakpointKey identifies a location breakpoint by file and line without
+// needing to format a "file:line" string on every lookup, since Line/SLine
+// look one up on every line the debugged program executes.
+type breakpointKey struct {
+	file string
+	line int
+}
+
+// parseBreakpointKey splits a "file:line" location into a breakpointKey, or
+// returns ok=false if location doesn't have that form (e.g. it's a bare
+// function name, destined for funcBreakpoints instead).
+func parseBreakpointKey(location string) (key breakpointKey, ok bool) {
+	idx := strings.LastIndex(location, ":")
+	if idx < 0 {
+		return breakpointKey{}, false
+	}
+	line, err := strconv.Atoi(location[idx+1:])
+	if err != nil {
+		return breakpointKey{}, false
+	}
+	return breakpointKey{file: location[:idx], line: line}, true
+}
+
+// Breakpoints holds location-based breakpoints, keyed by breakpointKey. Line
+// and SLine consult it whenever currentState is run, so a breakpoint set
+// here is respected no matter which followed (or unfollowed) goroutine
+// reaches that line. It's a sync.Map rather than a plain map guarded by a
+// mutex so that lookups on the hot "just run the program" path don't
+// contend with REPL goroutines mutating it.
+var Breakpoints sync.Map
+
+// funcBreakpoints holds function-name breakpoints, keyed by the
+// runtime.FuncForPC name EnterFunc/EnterFuncLit record for the function
+// being entered.
+var funcBreakpoints sync.Map
+
+var nextBreakpointID int32
+
+// funcBreakpointCount tracks how many entries funcBreakpoints holds, so that
+// EnterFunc/EnterFuncLit can tell whether a function breakpoint could
+// possibly match -- and so whether resolving a frame's real name is worth
+// its runtime.Caller/FuncForPC cost -- without ranging over funcBreakpoints
+// on every call.
+var funcBreakpointCount int32
+
+func hasFuncBreakpoints() bool {
+	return atomic.LoadInt32(&funcBreakpointCount) > 0
+}
+
+// breakpoint is a single entry in Breakpoints or funcBreakpoints. condition
+// is stored in an atomic.Value since "condition N EXPR" can rewrite it from
+// the REPL goroutine while an arbitrary program goroutine is concurrently
+// evaluating it in Line/SLine.
+type breakpoint struct {
+	id       int32
+	location string
+	hits     int64
+	cond     atomic.Value // string
+}
+
+func newBreakpoint(location, condition string) *breakpoint {
+	bp := &breakpoint{id: atomic.AddInt32(&nextBreakpointID, 1), location: location}
+	bp.cond.Store(condition)
+	return bp
+}
+
+func (b *breakpoint) condString() string {
+	if v := b.cond.Load(); v != nil {
+		return v.(string)
+	}
+	return ""
+}
+
+// hitLineBreakpoint reports whether a breakpoint registered at file:line
+// should stop the program, evaluating its condition (if any) against s. A
+// condition that fails to evaluate is treated as true, on the theory that a
+// breakpoint the user can't hit is worse than one that over-triggers with
+// an explanatory message.
+func hitLineBreakpoint(c *Context, s *Scope, file string, line int) bool {
+	v, ok := Breakpoints.Load(breakpointKey{file: file, line: line})
+	if !ok {
+		return false
+	}
+	bp := v.(*breakpoint)
+	matched, err := evalConditionSafe(bp, s, line)
+	if err != nil {
+		activeFrontend.Output(fmt.Sprintf("breakpoint %d: %v; stopping anyway", bp.id, err))
+		matched = true
+	}
+	if !matched {
+		return false
+	}
+	atomic.AddInt64(&bp.hits, 1)
+	atomic.StoreUint32(&currentGoroutine, c.goroutine)
+	atomic.StoreInt32(&currentState, step)
+	setStopReasonOverride("breakpoint")
+	return true
+}
+
+// checkFuncBreakpoint is consulted from EnterFunc/EnterFuncLit. Function
+// breakpoints don't support conditions: there's no scope yet to evaluate
+// one against at the moment a function is entered.
+func checkFuncBreakpoint(goroutine uint32, frame *Frame) {
+	bp := matchFuncBreakpoint(frame.FuncName)
+	if bp == nil {
+		return
+	}
+	atomic.AddInt64(&bp.hits, 1)
+	atomic.StoreUint32(&currentGoroutine, goroutine)
+	atomic.StoreInt32(&currentState, step)
+	setStopReasonOverride("breakpoint")
+}
+
+// matchFuncBreakpoint finds the breakpoint, if any, that "break FUNCNAME"
+// set for fullName, the fully-qualified runtime.FuncForPC name
+// (e.g. "main.foo" or "github.com/.../lib.Type.Method"). An exact match is
+// tried first, then a match on fullName's final "pkg.Func" or
+// "Type.Method" component, then its bare final identifier, so that "break
+// foo" or "break Type.Method" work without the caller needing to type the
+// full import path.
+func matchFuncBreakpoint(fullName string) *breakpoint {
+	if v, ok := funcBreakpoints.Load(fullName); ok {
+		return v.(*breakpoint)
+	}
+	var found *breakpoint
+	foundLen := -1
+	funcBreakpoints.Range(func(k, v interface{}) bool {
+		name := k.(string)
+		if (fullName == name || strings.HasSuffix(fullName, "."+name)) && len(name) > foundLen {
+			found, foundLen = v.(*breakpoint), len(name)
+		}
+		return true
+	})
+	return found
+}
+
+// cmdBreak implements "break FILE:LINE [if EXPR]" and "break FUNCNAME"
+// (also reachable as "set-breakpoint", the verb the JSON frontend
+// documents). FUNCNAME doesn't need to be the fully-qualified name
+// runtime.FuncForPC reports: matchFuncBreakpoint also matches it against
+// the tail of that name, so "break foo" or "break Type.Method" both work.
+// A condition is rejected for FUNCNAME (see checkFuncBreakpoint).
+// rest is everything after the command name.
+func cmdBreak(rest string) {
+	rest = strings.TrimSpace(rest)
+	if rest == "" {
+		activeFrontend.Output(`break: expected "break FILE:LINE [if EXPR]" or "break FUNCNAME"`)
+		return
+	}
+	location, condition := rest, ""
+	if idx := strings.Index(rest, " if "); idx >= 0 {
+		location = strings.TrimSpace(rest[:idx])
+		condition = strings.TrimSpace(rest[idx+len(" if "):])
+	}
+	key, isLocation := parseBreakpointKey(location)
+	if !isLocation && condition != "" {
+		activeFrontend.Output(fmt.Sprintf("break: %s is a function breakpoint; conditions are only supported on FILE:LINE breakpoints", location))
+		return
+	}
+	bp := newBreakpoint(location, condition)
+	if isLocation {
+		Breakpoints.Store(key, bp)
+	} else {
+		if _, exists := funcBreakpoints.Load(location); !exists {
+			atomic.AddInt32(&funcBreakpointCount, 1)
+		}
+		funcBreakpoints.Store(location, bp)
+	}
+	activeFrontend.Output(fmt.Sprintf("Breakpoint %d set at %s", bp.id, location))
+}
+
+func cmdListBreakpoints() {
+	var all []*breakpoint
+	collect := func(_, v interface{}) bool {
+		all = append(all, v.(*breakpoint))
+		return true
+	}
+	Breakpoints.Range(collect)
+	funcBreakpoints.Range(collect)
+	if len(all) == 0 {
+		activeFrontend.Output("no breakpoints set")
+		return
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].id < all[j].id })
+	var b strings.Builder
+	for _, bp := range all {
+		hits := atomic.LoadInt64(&bp.hits)
+		if cond := bp.condString(); cond != "" {
+			fmt.Fprintf(&b, "%d: %s if %s (%d hits)\n", bp.id, bp.location, cond, hits)
+		} else {
+			fmt.Fprintf(&b, "%d: %s (%d hits)\n", bp.id, bp.location, hits)
+		}
+	}
+	activeFrontend.Output(strings.TrimRight(b.String(), "\n"))
+}
+
+func cmdClear(arg string) {
+	id, err := strconv.Atoi(strings.TrimSpace(arg))
+	if err != nil {
+		activeFrontend.Output(fmt.Sprintf("clear: expected a breakpoint number, got %q", arg))
+		return
+	}
+	if deleteBreakpointByID(&Breakpoints, int32(id)) {
+		activeFrontend.Output(fmt.Sprintf("cleared breakpoint %d", id))
+		return
+	}
+	if deleteBreakpointByID(&funcBreakpoints, int32(id)) {
+		atomic.AddInt32(&funcBreakpointCount, -1)
+		activeFrontend.Output(fmt.Sprintf("cleared breakpoint %d", id))
+		return
+	}
+	activeFrontend.Output(fmt.Sprintf("clear: no breakpoint numbered %d", id))
+}
+
+func deleteBreakpointByID(m *sync.Map, id int32) bool {
+	found := false
+	m.Range(func(k, v interface{}) bool {
+		if v.(*breakpoint).id == id {
+			m.Delete(k)
+			found = true
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+func cmdCondition(idArg, expr string) {
+	id, err := strconv.Atoi(strings.TrimSpace(idArg))
+	if err != nil {
+		activeFrontend.Output(fmt.Sprintf("condition: expected a breakpoint number, got %q", idArg))
+		return
+	}
+	bp := findBreakpointByID(int32(id))
+	if bp == nil {
+		activeFrontend.Output(fmt.Sprintf("condition: no breakpoint numbered %d", id))
+		return
+	}
+	if _, ok := parseBreakpointKey(bp.location); !ok {
+		activeFrontend.Output(fmt.Sprintf("condition: breakpoint %d is a function breakpoint; conditions are only supported on FILE:LINE breakpoints", id))
+		return
+	}
+	bp.cond.Store(expr)
+	activeFrontend.Output(fmt.Sprintf("breakpoint %d now conditional on %q", id, expr))
+}
+
+func findBreakpointByID(id int32) *breakpoint {
+	var found *breakpoint
+	check := func(_, v interface{}) bool {
+		if v.(*breakpoint).id == id {
+			found = v.(*breakpoint)
+			return false
+		}
+		return true
+	}
+	Breakpoints.Range(check)
+	if found == nil {
+		funcBreakpoints.Range(check)
+	}
+	return found
+}
+
+// evalConditionSafe runs evalCondition and recovers from any panic it
+// raises, turning it into an error. It's the only path hitLineBreakpoint
+// uses to evaluate a condition, since that runs on the debugged program's
+// own goroutine: a panicking condition must not be allowed to take the
+// target program down with it.
+func evalConditionSafe(bp *breakpoint, s *Scope, currentLine int) (matched bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			matched, err = false, fmt.Errorf("evaluating condition %q: %v", bp.condString(), r)
+		}
+	}()
+	return evalCondition(bp.condString(), s, currentLine)
+}
+
+// evalCondition evaluates a minimal expression of the form "name OP
+// literal" (OP one of == != < <= > >=) against s as of currentLine. An
+// empty expr is always true (an unconditional breakpoint).
+func evalCondition(expr string, s *Scope, currentLine int) (bool, error) {
+	if strings.TrimSpace(expr) == "" {
+		return true, nil
+	}
+	node, err := parser.ParseExpr(expr)
+	if err != nil {
+		return false, fmt.Errorf("parsing condition %q: %v", expr, err)
+	}
+	bin, ok := node.(*ast.BinaryExpr)
+	if !ok {
+		return false, fmt.Errorf("condition %q must have the form NAME OP LITERAL", expr)
+	}
+	ident, ok := bin.X.(*ast.Ident)
+	if !ok {
+		return false, fmt.Errorf("condition %q must have the form NAME OP LITERAL", expr)
+	}
+	ptr, ok := s.getVar(ident.Name, currentLine)
+	if !ok {
+		return false, fmt.Errorf("condition %q: %s is not in scope", expr, ident.Name)
+	}
+	rhs, err := literalValue(bin.Y)
+	if err != nil {
+		return false, fmt.Errorf("condition %q: %v", expr, err)
+	}
+	return compareValues(reflect.ValueOf(dereference(ptr)), bin.Op, rhs)
+}
+
+func literalValue(expr ast.Expr) (interface{}, error) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		switch e.Kind {
+		case token.INT:
+			return strconv.ParseInt(e.Value, 0, 64)
+		case token.FLOAT:
+			return strconv.ParseFloat(e.Value, 64)
+		case token.STRING:
+			return strconv.Unquote(e.Value)
+		case token.CHAR:
+			s, err := strconv.Unquote(e.Value)
+			if err != nil || len(s) == 0 {
+				return nil, fmt.Errorf("invalid char literal %s", e.Value)
+			}
+			return []rune(s)[0], nil
+		}
+	case *ast.Ident:
+		switch e.Name {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	case *ast.UnaryExpr:
+		if e.Op == token.SUB {
+			v, err := literalValue(e.X)
+			if err != nil {
+				return nil, err
+			}
+			switch n := v.(type) {
+			case int64:
+				return -n, nil
+			case float64:
+				return -n, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("unsupported literal %T", expr)
+}
+
+func compareValues(lhs reflect.Value, op token.Token, rhs interface{}) (bool, error) {
+	rv := reflect.ValueOf(rhs)
+	if rv.Type() != lhs.Type() {
+		if !rv.Type().ConvertibleTo(lhs.Type()) {
+			return false, fmt.Errorf("cannot compare %s with %s", lhs.Type(), rv.Type())
+		}
+		rv = rv.Convert(lhs.Type())
+	}
+	var cmp int
+	switch lhs.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		cmp = compareInt64(lhs.Int(), rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		cmp = compareUint64(lhs.Uint(), rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		cmp = compareFloat64(lhs.Float(), rv.Float())
+	case reflect.String:
+		cmp = strings.Compare(lhs.String(), rv.String())
+	case reflect.Bool:
+		if op != token.EQL && op != token.NEQ {
+			return false, fmt.Errorf("operator %s is not supported for bool", op)
+		}
+		return (lhs.Bool() == rv.Bool()) == (op == token.EQL), nil
+	default:
+		return false, fmt.Errorf("comparisons are not supported for %s", lhs.Kind())
+	}
+	switch op {
+	case token.EQL:
+		return cmp == 0, nil
+	case token.NEQ:
+		return cmp != 0, nil
+	case token.LSS:
+		return cmp < 0, nil
+	case token.LEQ:
+		return cmp <= 0, nil
+	case token.GTR:
+		return cmp > 0, nil
+	case token.GEQ:
+		return cmp >= 0, nil
+	default:
+		return false, fmt.Errorf("unsupported operator %s", op)
+	}
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareUint64(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareFloat64(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,136 @@
+package godebug
+
+import (
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+func TestEvalCondition(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		declare func(s *Scope)
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "empty expression is always true",
+			expr: "",
+			want: true,
+		},
+		{
+			name: "int equal",
+			expr: "x == 5",
+			declare: func(s *Scope) {
+				x := 5
+				s.DeclareParam("x", &x)
+			},
+			want: true,
+		},
+		{
+			name: "int not equal",
+			expr: "x == 5",
+			declare: func(s *Scope) {
+				x := 6
+				s.DeclareParam("x", &x)
+			},
+			want: false,
+		},
+		{
+			name: "string comparison",
+			expr: `name != "bob"`,
+			declare: func(s *Scope) {
+				name := "alice"
+				s.DeclareParam("name", &name)
+			},
+			want: true,
+		},
+		{
+			name: "less than",
+			expr: "n < 10",
+			declare: func(s *Scope) {
+				n := 3
+				s.DeclareParam("n", &n)
+			},
+			want: true,
+		},
+		{
+			name:    "unknown variable errors",
+			expr:    "y == 1",
+			wantErr: true,
+		},
+		{
+			name: "malformed expression errors",
+			expr: "x +",
+			declare: func(s *Scope) {
+				x := 1
+				s.DeclareParam("x", &x)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := EnteringNewScope()
+			if tt.declare != nil {
+				tt.declare(s)
+			}
+			got, err := evalCondition(tt.expr, s, 0)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("evalCondition(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("evalCondition(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		lhs     interface{}
+		op      token.Token
+		rhs     interface{}
+		want    bool
+		wantErr bool
+	}{
+		{name: "int eq", lhs: 5, op: token.EQL, rhs: int64(5), want: true},
+		{name: "differently-sized int still comparable", lhs: int32(5), op: token.EQL, rhs: int64(5), want: true},
+		{name: "float less", lhs: 1.5, op: token.LSS, rhs: 2.5, want: true},
+		{name: "string greater", lhs: "b", op: token.GTR, rhs: "a", want: true},
+		{name: "bool eq", lhs: true, op: token.EQL, rhs: true, want: true},
+		{name: "bool unsupported op errors instead of panicking", lhs: true, op: token.LSS, rhs: false, wantErr: true},
+		{name: "incompatible types error instead of panicking", lhs: 5, op: token.EQL, rhs: "5", wantErr: true},
+		{name: "unsupported kind errors", lhs: []int{1}, op: token.EQL, rhs: []int{1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := compareValues(reflect.ValueOf(tt.lhs), tt.op, tt.rhs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("compareValues(%v, %v, %v) error = %v, wantErr %v", tt.lhs, tt.op, tt.rhs, err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("compareValues(%v, %v, %v) = %v, want %v", tt.lhs, tt.op, tt.rhs, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalConditionSafeReportsErrorsWithoutPanicking(t *testing.T) {
+	// hitLineBreakpoint always goes through evalConditionSafe rather than
+	// evalCondition directly, on the theory that a malformed condition must
+	// report an error rather than take the debugged program down with it.
+	s := EnteringNewScope()
+	bp := newBreakpoint("main.go:1", "x +")
+	x := 1
+	s.DeclareParam("x", &x)
+	matched, err := evalConditionSafe(bp, s, 0)
+	if err == nil {
+		t.Fatalf("evalConditionSafe(%q) = %v, nil error, want an error", bp.condString(), matched)
+	}
+	if matched {
+		t.Errorf("evalConditionSafe on an unevaluable condition should report matched=false, got true")
+	}
+}
@@ -0,0 +1,229 @@
+package godebug
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// funcRegistry maps a function's registered name to the reflect.Value that
+// invokes it, populated by RegisterFunc. It's read from the "call" REPL
+// command and written once at startup by generated code, so a sync-free map
+// guarded by the package init ordering would be unsafe; we use the same
+// sync.Map the breakpoint tables use for the same reason.
+var funcRegistry sync.Map
+
+// RegisterFunc makes fn callable from the REPL's "call" command under name.
+// The code generator emits one call to RegisterFunc per top-level function
+// and method in the instrumented package, using the same qualified name
+// (e.g. "pkg.Func" or "pkg.Type.Method") that "call" parses out of a
+// selector expression.
+func RegisterFunc(name string, fn interface{}) {
+	funcRegistry.Store(name, reflect.ValueOf(fn))
+}
+
+func init() {
+	registerCommand([]string{"call"}, func(cmd Command, scope *Scope, currentLine int) bool {
+		cmdCall(cmd.Raw, scope, currentLine)
+		return false
+	})
+}
+
+// cmdCall implements the "call" REPL command: it evaluates a single Go
+// function call expression against the functions RegisterFunc knows about
+// and the variables visible in the current (or selected) frame, entirely
+// via reflect, since godebug runs in-process rather than attaching to one.
+//
+// Only "ident(args...)" and dotted forms like "pkgident.ident(args...)" or
+// "pkgident.Type.Method(args...)" are accepted, and each argument must be a
+// scope variable or an int/float/string/bool/nil literal -- there is no
+// support for calling a method value obtained from an interface (reflect
+// has no way to recover the concrete method from one without a value in
+// hand) or for composite-literal arguments.
+func cmdCall(raw string, scope *Scope, currentLine int) {
+	expr := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(raw), "call"))
+	if expr == "" {
+		activeFrontend.Output(`call: expected a function call, e.g. "call pkg.Func(x, 5)"`)
+		return
+	}
+	node, err := parser.ParseExpr(expr)
+	if err != nil {
+		activeFrontend.Output(fmt.Sprintf("call: %v", err))
+		return
+	}
+	call, ok := node.(*ast.CallExpr)
+	if !ok {
+		activeFrontend.Output(`call: expected a function call of the form "ident(args...)" or "pkgident.ident(args...)"`)
+		return
+	}
+	name, ok := calleeName(call.Fun)
+	if !ok {
+		activeFrontend.Output(`call: the function being called must be "ident" or a dotted path like "pkgident.ident"`)
+		return
+	}
+	v, ok := funcRegistry.Load(name)
+	if !ok {
+		activeFrontend.Output(fmt.Sprintf("call: no registered function named %q", name))
+		return
+	}
+	fn := v.(reflect.Value)
+	fnType := fn.Type()
+	if fnType.Kind() != reflect.Func {
+		activeFrontend.Output(fmt.Sprintf("call: %q is not a function", name))
+		return
+	}
+	numIn := fnType.NumIn()
+	numFixed := numIn
+	if fnType.IsVariadic() {
+		numFixed--
+	}
+	if len(call.Args) < numFixed || (!fnType.IsVariadic() && len(call.Args) != numFixed) {
+		activeFrontend.Output(fmt.Sprintf("call: %q takes %s%d argument(s), got %d", name, variadicPrefix(fnType), numFixed, len(call.Args)))
+		return
+	}
+	activeScope, activeLine := frameScope(scope, currentLine)
+	args := make([]reflect.Value, len(call.Args))
+	for i, argExpr := range call.Args {
+		var want reflect.Type
+		if fnType.IsVariadic() && i >= numFixed {
+			want = fnType.In(numIn - 1).Elem()
+		} else {
+			want = fnType.In(i)
+		}
+		arg, err := resolveCallArg(argExpr, activeScope, activeLine, want)
+		if err != nil {
+			activeFrontend.Output(fmt.Sprintf("call: argument %d: %v", i+1, err))
+			return
+		}
+		args[i] = arg
+	}
+	results := fn.Call(args)
+	if len(results) == 0 {
+		activeFrontend.Output("call: (no return value)")
+		return
+	}
+	parts := make([]string, len(results))
+	for i, r := range results {
+		parts[i] = fmt.Sprint(r.Interface())
+	}
+	activeFrontend.Output(strings.Join(parts, ", "))
+}
+
+// variadicPrefix returns "at least " for a variadic function's argument
+// count in an error message, or "" otherwise.
+func variadicPrefix(fnType reflect.Type) string {
+	if fnType.IsVariadic() {
+		return "at least "
+	}
+	return ""
+}
+
+// calleeName turns the function side of a call expression into the name
+// RegisterFunc was given for it: a bare identifier for "ident", or the
+// dotted path for a (possibly multiply nested) selector expression, e.g.
+// "pkg.ident" or "pkg.Type.Method".
+func calleeName(fun ast.Expr) (string, bool) {
+	var parts []string
+	for {
+		switch e := fun.(type) {
+		case *ast.Ident:
+			parts = append([]string{e.Name}, parts...)
+			return strings.Join(parts, "."), true
+		case *ast.SelectorExpr:
+			parts = append([]string{e.Sel.Name}, parts...)
+			fun = e.X
+		default:
+			return "", false
+		}
+	}
+}
+
+// resolveCallArg evaluates one call argument as either a variable visible
+// in scope at currentLine, or an int/float/string/bool/nil literal, and
+// converts it to want, the parameter type it will be passed as.
+func resolveCallArg(expr ast.Expr, scope *Scope, currentLine int, want reflect.Type) (reflect.Value, error) {
+	neg := false
+	if u, ok := expr.(*ast.UnaryExpr); ok && u.Op == token.SUB {
+		expr = u.X
+		neg = true
+	}
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if neg {
+			return reflect.Value{}, fmt.Errorf("unary minus is only supported on numeric literals")
+		}
+		switch e.Name {
+		case "nil":
+			switch want.Kind() {
+			case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+				return reflect.Zero(want), nil
+			default:
+				return reflect.Value{}, fmt.Errorf("nil is not assignable to %s", want)
+			}
+		case "true", "false":
+			return convertCallArg(reflect.ValueOf(e.Name == "true"), want)
+		default:
+			v, ok := scope.getVar(e.Name, currentLine)
+			if !ok {
+				return reflect.Value{}, fmt.Errorf("no variable named %q in scope", e.Name)
+			}
+			return convertCallArg(reflect.ValueOf(dereference(v)), want)
+		}
+	case *ast.BasicLit:
+		return literalCallArg(e, neg, want)
+	default:
+		return reflect.Value{}, fmt.Errorf("unsupported argument expression; only variables and literals are supported")
+	}
+}
+
+func literalCallArg(lit *ast.BasicLit, neg bool, want reflect.Type) (reflect.Value, error) {
+	switch lit.Kind {
+	case token.INT:
+		n, err := strconv.ParseInt(lit.Value, 0, 64)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if neg {
+			n = -n
+		}
+		return convertCallArg(reflect.ValueOf(n), want)
+	case token.FLOAT:
+		f, err := strconv.ParseFloat(lit.Value, 64)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if neg {
+			f = -f
+		}
+		return convertCallArg(reflect.ValueOf(f), want)
+	case token.STRING:
+		if neg {
+			return reflect.Value{}, fmt.Errorf("unary minus is only supported on numeric literals")
+		}
+		s, err := strconv.Unquote(lit.Value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return convertCallArg(reflect.ValueOf(s), want)
+	default:
+		return reflect.Value{}, fmt.Errorf("unsupported literal %q", lit.Value)
+	}
+}
+
+// convertCallArg converts v to want if the two types aren't identical but
+// v's type converts to want, e.g. an untyped int literal's default type
+// int64 being passed to a parameter declared as int32.
+func convertCallArg(v reflect.Value, want reflect.Type) (reflect.Value, error) {
+	if v.Type() == want {
+		return v, nil
+	}
+	if v.Type().ConvertibleTo(want) {
+		return v.Convert(want), nil
+	}
+	return reflect.Value{}, fmt.Errorf("cannot use %s as %s", v.Type(), want)
+}
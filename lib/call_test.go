@@ -0,0 +1,160 @@
+package godebug
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func mustParseExpr(t *testing.T, expr string) ast.Expr {
+	t.Helper()
+	node, err := parser.ParseExpr(expr)
+	if err != nil {
+		t.Fatalf("ParseExpr(%q): %v", expr, err)
+	}
+	return node
+}
+
+func TestResolveCallArg(t *testing.T) {
+	scope := EnteringNewScope()
+	x := 42
+	name := "gopher"
+	scope.DeclareParam("x", &x, "name", &name)
+
+	tests := []struct {
+		name      string
+		expr      string
+		want      reflect.Type
+		wantValue interface{}
+		wantErr   bool
+	}{
+		{name: "variable coerced to a narrower int type", expr: "x", want: reflect.TypeOf(int32(0)), wantValue: int32(42)},
+		{name: "string variable", expr: "name", want: reflect.TypeOf(""), wantValue: "gopher"},
+		{name: "int literal", expr: "7", want: reflect.TypeOf(int64(0)), wantValue: int64(7)},
+		{name: "negative int literal", expr: "-7", want: reflect.TypeOf(int64(0)), wantValue: int64(-7)},
+		{name: "float literal coerced to float32", expr: "1.5", want: reflect.TypeOf(float32(0)), wantValue: float32(1.5)},
+		{name: "bool literal", expr: "true", want: reflect.TypeOf(false), wantValue: true},
+		{name: "nil for a pointer parameter", expr: "nil", want: reflect.TypeOf((*int)(nil))},
+		{name: "nil rejected for a non-nilable parameter", expr: "nil", want: reflect.TypeOf(0), wantErr: true},
+		{name: "unknown variable errors", expr: "missing", want: reflect.TypeOf(0), wantErr: true},
+		{name: "call expression is not a supported argument", expr: "foo()", want: reflect.TypeOf(0), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr := mustParseExpr(t, tt.expr)
+			got, err := resolveCallArg(expr, scope, 0, tt.want)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveCallArg(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Type() != tt.want {
+				t.Errorf("resolveCallArg(%q) type = %s, want %s", tt.expr, got.Type(), tt.want)
+			}
+			if tt.wantValue != nil && got.Interface() != tt.wantValue {
+				t.Errorf("resolveCallArg(%q) = %v, want %v", tt.expr, got.Interface(), tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestConvertCallArg(t *testing.T) {
+	tests := []struct {
+		name    string
+		v       interface{}
+		want    reflect.Type
+		wantErr bool
+	}{
+		{name: "identical type is returned as-is", v: int64(5), want: reflect.TypeOf(int64(0))},
+		{name: "convertible numeric type is converted", v: int64(5), want: reflect.TypeOf(int32(0))},
+		{name: "inconvertible type errors", v: int64(5), want: reflect.TypeOf(false), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertCallArg(reflect.ValueOf(tt.v), tt.want)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("convertCallArg(%v, %s) error = %v, wantErr %v", tt.v, tt.want, err, tt.wantErr)
+			}
+			if err == nil && got.Type() != tt.want {
+				t.Errorf("convertCallArg(%v, %s) type = %s, want %s", tt.v, tt.want, got.Type(), tt.want)
+			}
+		})
+	}
+}
+
+func TestCmdCallVariadic(t *testing.T) {
+	prevFrontend := activeFrontend
+	fe := &captureFrontend{}
+	activeFrontend = fe
+	defer func() { activeFrontend = prevFrontend }()
+
+	RegisterFunc("calltest.Sum", func(prefix string, nums ...int) string {
+		total := 0
+		for _, n := range nums {
+			total += n
+		}
+		return fmt.Sprintf("%s%d", prefix, total)
+	})
+	defer funcRegistry.Delete("calltest.Sum")
+
+	scope := EnteringNewScope()
+
+	tests := []struct {
+		name        string
+		call        string
+		wantOutput  string
+		wantErrText string
+	}{
+		{name: "variadic arguments omitted entirely", call: `call calltest.Sum("total=")`, wantOutput: "total=0"},
+		{name: "several variadic arguments", call: `call calltest.Sum("total=", 1, 2, 3)`, wantOutput: "total=6"},
+		{name: "missing the fixed argument errors instead of panicking", call: `call calltest.Sum()`, wantErrText: "at least 1 argument"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fe.texts = nil
+			cmdCall(tt.call, scope, 0)
+			if len(fe.texts) != 1 {
+				t.Fatalf("cmdCall(%q) produced %d Output calls, want 1: %v", tt.call, len(fe.texts), fe.texts)
+			}
+			got := fe.texts[0]
+			if tt.wantErrText != "" {
+				if !strings.Contains(got, tt.wantErrText) {
+					t.Errorf("cmdCall(%q) = %q, want it to mention %q", tt.call, got, tt.wantErrText)
+				}
+				return
+			}
+			if got != tt.wantOutput {
+				t.Errorf("cmdCall(%q) = %q, want %q", tt.call, got, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestCalleeName(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+		ok   bool
+	}{
+		{expr: "foo", want: "foo", ok: true},
+		{expr: "pkg.Func", want: "pkg.Func", ok: true},
+		{expr: "pkg.Type.Method", want: "pkg.Type.Method", ok: true},
+		{expr: "foo()", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr := mustParseExpr(t, tt.expr)
+			got, ok := calleeName(expr)
+			if ok != tt.ok {
+				t.Fatalf("calleeName(%q) ok = %v, want %v", tt.expr, ok, tt.ok)
+			}
+			if ok && got != tt.want {
+				t.Errorf("calleeName(%q) = %q, want %q", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"os"
 	"reflect"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 
 	"github.com/jtolds/gls"
@@ -15,14 +17,26 @@ import (
 
 // Scope represents a lexical scope for variable bindings.
 type Scope struct {
-	vars   map[string]interface{}
+	vars   map[string]*varBinding
 	parent *Scope
 }
 
+// varBinding records a single variable's pointer along with enough
+// information to decide when it should become visible to the debugger.
+// Variables declared with DeclareAt are hidden from lookups until the
+// debugger is paused strictly after declLine, so that e.g. `b := f()` does
+// not show a misleading zero value for b while execution is still on that
+// line. Function parameters (DeclareParam) are visible immediately.
+type varBinding struct {
+	ptr      interface{}
+	declLine int
+	isParam  bool
+}
+
 // EnteringNewScope returns a new Scope and internally sets
 // the current scope to be the returned scope.
 func EnteringNewScope() *Scope {
-	return &Scope{vars: make(map[string]interface{})}
+	return &Scope{vars: make(map[string]*varBinding)}
 }
 
 // EnteringNewChildScope returns a new Scope that is the
@@ -30,37 +44,75 @@ func EnteringNewScope() *Scope {
 // the returned scope.
 func (s *Scope) EnteringNewChildScope() *Scope {
 	return &Scope{
-		vars:   make(map[string]interface{}),
+		vars:   make(map[string]*varBinding),
 		parent: s,
 	}
 }
 
-func (s *Scope) getVar(name string) (i interface{}, ok bool) {
+// getVar looks up name, visible as of currentLine: a non-parameter binding
+// declared on or after currentLine is treated as not yet in scope, matching
+// Go's own post-declaration variable semantics (the same fix Delve applied
+// for Go 1.15). A name hidden in this scope is not visible via an outer
+// scope of the same name either, since the inner declaration already shadows
+// it.
+func (s *Scope) getVar(name string, currentLine int) (i interface{}, ok bool) {
 	for scope := s; scope != nil; scope = scope.parent {
-		if i, ok = scope.vars[name]; ok {
-			return i, true
+		b, found := scope.vars[name]
+		if !found {
+			continue
+		}
+		if !b.isParam && b.declLine >= currentLine {
+			return nil, false
 		}
+		return b.ptr, true
 	}
 	return nil, false
 }
 
-// Declare creates new variable bindings in s from a list of name, value pairs.
-// The values should be pointers to the values in the program rather than copies
-// of them so that s can track changes to them.
-func (s *Scope) Declare(namevalue ...interface{}) {
+func (s *Scope) declare(isParam bool, declLine int, namevalue ...interface{}) {
 	var i int
 	for i = 0; i+1 < len(namevalue); i += 2 {
 		name, ok := namevalue[i].(string)
 		if !ok {
 			panic("programming error: got odd-numbered argument to RecordVars that was not a string")
 		}
-		s.vars[name] = namevalue[i+1]
+		s.vars[name] = &varBinding{ptr: namevalue[i+1], declLine: declLine, isParam: isParam}
 	}
 	if i != len(namevalue) {
 		panic("programming error: called RecordVars with odd number of arguments")
 	}
 }
 
+// DeclareParam creates new bindings in s for function parameters (and named
+// return values), which are visible to the debugger for the entire duration
+// of the function, including on the line where the function is entered.
+// The values should be pointers to the values in the program rather than
+// copies of them so that s can track changes to them.
+func (s *Scope) DeclareParam(namevalue ...interface{}) {
+	s.declare(true, 0, namevalue...)
+}
+
+// DeclareAt creates new variable bindings in s from a list of name, value
+// pairs, recording that the declaration happened on line declLine. The
+// bindings stay hidden from p/variable-lookup commands until the debugger
+// is paused on a line strictly after declLine. The values should be
+// pointers to the values in the program rather than copies of them so that
+// s can track changes to them.
+func (s *Scope) DeclareAt(declLine int, namevalue ...interface{}) {
+	s.declare(false, declLine, namevalue...)
+}
+
+// Declare is the pre-post-declaration-visibility form of DeclareAt, kept so
+// that already-generated code calling scope.Declare(...) keeps compiling
+// against this package. New generated code should call DeclareParam or
+// DeclareAt instead, which is what lets the debugger hide a variable until
+// its declaration line has actually run.
+//
+// Deprecated: use DeclareParam or DeclareAt.
+func (s *Scope) Declare(namevalue ...interface{}) {
+	s.DeclareAt(0, namevalue...)
+}
+
 const (
 	run int32 = iota
 	next
@@ -77,6 +129,123 @@ var (
 	ids              idPool
 )
 
+// Frame describes one entry in a followed goroutine's call stack, as
+// maintained by EnterFunc/EnterFuncLit/ExitFunc. Scope and Line are updated
+// whenever Line/SLine stop the goroutine (and on every Line/SLine call while
+// a trace is running), so that the innermost frame reflects where the
+// goroutine is currently paused.
+type Frame struct {
+	Scope    *Scope
+	File     string
+	Line     int
+	FuncName string
+}
+
+var (
+	framesMu sync.Mutex
+	frames   = make(map[uint32][]*Frame)
+
+	// selectedFrame is the index (0 = innermost) into the current
+	// goroutine's stack that p/print and variable lookups should use. It is
+	// reset to 0 whenever the debugger stops at a new Line/SLine. It's read
+	// and written from both the debugged program's goroutine and whichever
+	// goroutine is driving the REPL, so all access goes through atomic.
+	selectedFrame int32
+
+	knownGoroutinesMu sync.Mutex
+	knownGoroutines   = make(map[uint32]bool)
+)
+
+func registerGoroutine(id uint32) {
+	knownGoroutinesMu.Lock()
+	knownGoroutines[id] = true
+	knownGoroutinesMu.Unlock()
+}
+
+func unregisterGoroutine(id uint32) {
+	knownGoroutinesMu.Lock()
+	delete(knownGoroutines, id)
+	knownGoroutinesMu.Unlock()
+	framesMu.Lock()
+	delete(frames, id)
+	framesMu.Unlock()
+}
+
+// callerFrame resolves the file, line, and function name skip frames up the
+// call stack, as runtime.Caller/FuncForPC would report them. It's split out
+// from pushFrame because EnterFunc/EnterFuncLit sometimes skip this lookup
+// (see needsFuncName) without also skipping the stack push, so that
+// pushFrame/popFrame stay balanced no matter how currentState changes
+// between a function's entry and its exit.
+func callerFrame(skip int) (file string, line int, funcName string) {
+	pc, file, line, ok := runtime.Caller(skip)
+	funcName = "???"
+	if ok {
+		if fn := runtime.FuncForPC(pc); fn != nil {
+			funcName = fn.Name()
+		}
+	}
+	return file, line, funcName
+}
+
+// needsFuncName reports whether EnterFunc/EnterFuncLit should pay for
+// callerFrame's stack walk and symbol lookup to name goroutine's
+// newly-entered frame: a running trace needs a real name for every region,
+// bt/goroutines need one for whichever goroutine the debugger is paused on
+// (or was last following), and a function breakpoint check -- which only
+// happens while currentState is run -- needs one to match against.
+// Otherwise, in the common case of free-running code nobody is watching,
+// the frame gets a "???" placeholder instead, since nothing will read it.
+func needsFuncName(goroutine uint32, state int32) bool {
+	return atomic.LoadInt32(&tracingEnabled) == 1 ||
+		state != run ||
+		goroutine == atomic.LoadUint32(&currentGoroutine) ||
+		hasFuncBreakpoints()
+}
+
+// pushFrame records a new stack entry for goroutine at the given source
+// position and function name. EnterFunc/EnterFuncLit always call this, even
+// when needsFuncName told them to skip resolving a real funcName, so that
+// the stack frame push/pop pairing never depends on currentState staying
+// put for the duration of the call.
+func pushFrame(goroutine uint32, file string, line int, funcName string) *Frame {
+	f := &Frame{File: file, Line: line, FuncName: funcName}
+	framesMu.Lock()
+	frames[goroutine] = append(frames[goroutine], f)
+	framesMu.Unlock()
+	return f
+}
+
+func popFrame(goroutine uint32) {
+	framesMu.Lock()
+	if stack := frames[goroutine]; len(stack) > 0 {
+		frames[goroutine] = stack[:len(stack)-1]
+	}
+	framesMu.Unlock()
+}
+
+// updateTopFrame records the scope and source position the given goroutine
+// is currently paused at, so that bt/frame can report accurate locations.
+func updateTopFrame(goroutine uint32, scope *Scope, line int) {
+	framesMu.Lock()
+	defer framesMu.Unlock()
+	stack := frames[goroutine]
+	if len(stack) == 0 {
+		return
+	}
+	top := stack[len(stack)-1]
+	top.Scope = scope
+	top.Line = line
+}
+
+func goroutineStack(goroutine uint32) []*Frame {
+	framesMu.Lock()
+	defer framesMu.Unlock()
+	stack := make([]*Frame, len(frames[goroutine]))
+	copy(stack, frames[goroutine])
+	return stack
+}
+
 // EnterFunc marks the beginning of a function. Calling fn should be equivalent to running
 // the function that is being entered. If proceed is false, EnterFunc did in fact call
 // fn, and so the caller of EnterFunc should return immediately rather than proceed to
@@ -101,13 +270,30 @@ func EnterFunc(fn func()) (ctx *Context, proceed bool) {
 		// invoke fn, which means the caller should not proceed. After running it, return false.
 		id := uint32(ids.Acquire())
 		defer ids.Release(uint(id))
+		registerGoroutine(id)
+		defer unregisterGoroutine(id)
+		traceGoStart(id)
+		defer traceGoEnd(id)
 		context.SetValues(gls.Values{goroutineKey: id}, fn)
 		return nil, false
 	}
-	if val.(uint32) == atomic.LoadUint32(&currentGoroutine) && currentState != run {
+	goroutine := val.(uint32)
+	state := atomic.LoadInt32(&currentState)
+	var file string
+	var line int
+	funcName := "???"
+	if needsFuncName(goroutine, state) {
+		file, line, funcName = callerFrame(1)
+	}
+	frame := pushFrame(goroutine, file, line, funcName)
+	traceRegionBegin(goroutine, frame.FuncName)
+	if state == run {
+		checkFuncBreakpoint(goroutine, frame)
+	}
+	if goroutine == atomic.LoadUint32(&currentGoroutine) && state != run {
 		currentDepth++
 	}
-	return &Context{goroutine: val.(uint32)}, true
+	return &Context{goroutine: goroutine}, true
 }
 
 // EnterFuncLit is like EnterFunc, but intended for function literals. The passed callback takes a *Context rather than no input.
@@ -116,30 +302,49 @@ func EnterFuncLit(fn func(*Context)) (ctx *Context, proceed bool) {
 	if !ok {
 		id := uint32(ids.Acquire())
 		defer ids.Release(uint(id))
+		registerGoroutine(id)
+		defer unregisterGoroutine(id)
+		traceGoStart(id)
+		defer traceGoEnd(id)
 		context.SetValues(gls.Values{goroutineKey: id}, func() {
 			fn(&Context{goroutine: id})
 		})
 		return nil, false
 	}
-	if val.(uint32) == atomic.LoadUint32(&currentGoroutine) && currentState != run {
+	goroutine := val.(uint32)
+	state := atomic.LoadInt32(&currentState)
+	var file string
+	var line int
+	funcName := "???"
+	if needsFuncName(goroutine, state) {
+		file, line, funcName = callerFrame(1)
+	}
+	frame := pushFrame(goroutine, file, line, funcName)
+	traceRegionBegin(goroutine, frame.FuncName)
+	if state == run {
+		checkFuncBreakpoint(goroutine, frame)
+	}
+	if goroutine == atomic.LoadUint32(&currentGoroutine) && state != run {
 		currentDepth++
 	}
-	return &Context{goroutine: val.(uint32)}, true
+	return &Context{goroutine: goroutine}, true
 }
 
 // ExitFunc marks the end of a function.
 func ExitFunc() {
-	if atomic.LoadInt32(&currentState) == run {
-		return
-	}
 	val, ok := context.GetValue(goroutineKey)
 	if !ok {
 		panic("Logic error in the debugger. Sorry! Let me know about this in the github issue tracker.")
 	}
+	popFrame(val.(uint32))
+	traceRegionEnd(val.(uint32))
+	if atomic.LoadInt32(&currentState) == run {
+		return
+	}
 	if val.(uint32) != atomic.LoadUint32(&currentGoroutine) {
 		return
 	}
-	if currentState == next && currentDepth == debuggerDepth {
+	if atomic.LoadInt32(&currentState) == next && currentDepth == debuggerDepth {
 		debuggerDepth--
 	}
 	currentDepth--
@@ -152,15 +357,64 @@ type Context struct {
 
 // Line marks a normal line where the debugger might pause.
 func Line(c *Context, s *Scope) {
-	if atomic.LoadUint32(&currentGoroutine) != c.goroutine {
-		return
+	_, file, line, _ := runtime.Caller(1)
+	// updateTopFrame takes framesMu and records frame state that's only
+	// ever read while the debugger is stopped (bt, frame, goroutines, list),
+	// so skip it on the common "just running" path unless a trace is running.
+	tracing := atomic.LoadInt32(&tracingEnabled) == 1
+	if tracing {
+		updateTopFrame(c.goroutine, s, line)
 	}
-	if currentState == run || (currentState == next && currentDepth != debuggerDepth) {
-		return
+	if atomic.LoadInt32(&currentState) == run {
+		if !hitLineBreakpoint(c, s, file, line) {
+			return
+		}
+	} else {
+		if atomic.LoadUint32(&currentGoroutine) != c.goroutine {
+			return
+		}
+		if atomic.LoadInt32(&currentState) == next && currentDepth != debuggerDepth {
+			return
+		}
+	}
+	if !tracing {
+		updateTopFrame(c.goroutine, s, line)
 	}
 	debuggerDepth = currentDepth
-	printLine()
-	waitForInput(s)
+	atomic.StoreInt32(&selectedFrame, 0)
+	if tracing {
+		traceUserLog(c.goroutine, file, line, sourceLineText(file, line))
+	}
+	activeFrontend.Stopped(file, line, stopReason(), s)
+	waitForInput(s, line)
+}
+
+// stopReasonOverride lets a breakpoint hit report "breakpoint" instead of
+// the step/next reason stopReason would otherwise derive from currentState.
+// It's written from the debugged program's goroutine (hitLineBreakpoint,
+// checkFuncBreakpoint) and read from whichever goroutine calls Line/SLine
+// next, so it's stored in an atomic.Value rather than a plain string.
+var stopReasonOverride atomic.Value // string
+
+func setStopReasonOverride(reason string) {
+	stopReasonOverride.Store(reason)
+}
+
+// stopReason describes, for the benefit of frontends, why the debugger just
+// paused.
+func stopReason() string {
+	if v := stopReasonOverride.Load(); v != nil && v.(string) != "" {
+		stopReasonOverride.Store("")
+		return v.(string)
+	}
+	switch atomic.LoadInt32(&currentState) {
+	case step:
+		return "step"
+	case next:
+		return "next"
+	default:
+		return "stop"
+	}
 }
 
 var skipNextElseIfExpr bool
@@ -168,7 +422,7 @@ var skipNextElseIfExpr bool
 // ElseIfSimpleStmt marks a simple statement preceding an "else if" expression.
 func ElseIfSimpleStmt(c *Context, s *Scope, line string) {
 	SLine(c, s, line)
-	if currentState == next {
+	if atomic.LoadInt32(&currentState) == next {
 		skipNextElseIfExpr = true
 	}
 }
@@ -182,15 +436,36 @@ func ElseIfExpr(c *Context, s *Scope, line string) {
 	SLine(c, s, line)
 }
 
-// SLine is like Line, except that the debugger should print the provided line rather than
-// reading the next line from the source code.
+// SLine is like Line, except that the call site knows the exact line being
+// run (e.g. a synthesized "else if" line) rather than leaving the frontend
+// to find it in the source file. Since the caller site and the line it
+// describes are always the same generated file/line pair, frontends can
+// derive an equivalent line of text from file/line alone. The line argument
+// is kept for source compatibility with existing generated code, and is the
+// text recorded for the line in a running trace.
 func SLine(c *Context, s *Scope, line string) {
-	if currentState == run || (currentState == next && currentDepth != debuggerDepth) {
+	_, file, lineNo, _ := runtime.Caller(1)
+	tracing := atomic.LoadInt32(&tracingEnabled) == 1
+	if tracing {
+		updateTopFrame(c.goroutine, s, lineNo)
+	}
+	if atomic.LoadInt32(&currentState) == run {
+		if !hitLineBreakpoint(c, s, file, lineNo) {
+			return
+		}
+	} else if atomic.LoadInt32(&currentState) == next && currentDepth != debuggerDepth {
 		return
 	}
+	if !tracing {
+		updateTopFrame(c.goroutine, s, lineNo)
+	}
 	debuggerDepth = currentDepth
-	fmt.Println("->", line)
-	waitForInput(s)
+	atomic.StoreInt32(&selectedFrame, 0)
+	if tracing {
+		traceUserLog(c.goroutine, file, lineNo, line)
+	}
+	activeFrontend.Stopped(file, lineNo, stopReason(), s)
+	waitForInput(s, lineNo)
 }
 
 // SetTrace is the entrypoint to the debugger. The code generator converts
@@ -204,59 +479,163 @@ func SetTraceGen(ctx *Context) {
 		return
 	}
 	atomic.StoreUint32(&currentGoroutine, ctx.goroutine)
-	currentState = step
-}
-
-var input *bufio.Scanner
-
-func init() {
-	input = bufio.NewScanner(os.Stdin)
+	atomic.StoreInt32(&currentState, step)
 }
 
-func waitForInput(scope *Scope) {
+func waitForInput(scope *Scope, currentLine int) {
 	for {
-		fmt.Print("(godebug) ")
-		if !input.Scan() {
-			fmt.Println("quitting session")
-			currentState = run
-			return
+		cmd := activeFrontend.NextCommand()
+		if cmd.Name == "" {
+			continue
 		}
-		s := input.Text()
-		switch s {
-		case "n", "next":
-			currentState = next
-			return
-		case "s", "step":
-			currentState = step
+		if cmd.Name == "quit" {
+			activeFrontend.Output("quitting session")
+			atomic.StoreInt32(&currentState, run)
 			return
 		}
-		if v, ok := scope.getVar(strings.TrimSpace(s)); ok {
-			fmt.Println(dereference(v))
+		if handler, ok := commandTable[cmd.Name]; ok {
+			if handler(cmd, scope, currentLine) {
+				return
+			}
 			continue
 		}
-		var cmd, name string
-		n, _ := fmt.Sscan(s, &cmd, &name)
-		if n == 2 && (cmd == "p" || cmd == "print") {
-			if v, ok := scope.getVar(strings.TrimSpace(name)); ok {
-				fmt.Println(dereference(v))
-				continue
-			}
+		if len(cmd.Args) == 0 && printVar(scope, currentLine, cmd.Name) {
+			continue
 		}
-		fmt.Printf("Command not recognized, sorry! You typed: %q\n", s)
+		activeFrontend.Output(fmt.Sprintf("Command not recognized, sorry! You typed: %q", cmd.Raw))
 	}
 }
 
-func dereference(i interface{}) interface{} {
-	return reflect.ValueOf(i).Elem().Interface()
+// printVar looks up name in the scope/line that frameScope resolves to and
+// reports it through the active frontend. It reports whether name was found.
+func printVar(scope *Scope, currentLine int, name string) bool {
+	activeScope, activeLine := frameScope(scope, currentLine)
+	v, ok := activeScope.getVar(strings.TrimSpace(name), activeLine)
+	if !ok {
+		return false
+	}
+	value := dereference(v)
+	if vf, ok := activeFrontend.(valueFrontend); ok {
+		vf.Value(name, value)
+		return true
+	}
+	activeFrontend.Output(fmt.Sprint(value))
+	return true
 }
 
-func printLine() {
-	_, file, line, ok := runtime.Caller(2)
-	if !ok {
-		fmt.Println("Hmm, something is broken. Failed to identify current source line.")
+// frameScope returns the scope and current line that p/print and bare
+// variable lookups should use: the live scope and line passed to the
+// current Line/SLine call if the user hasn't selected a different frame
+// with "frame N", or the scope and line recorded for the selected frame
+// otherwise.
+func frameScope(liveScope *Scope, liveLine int) (*Scope, int) {
+	selected := atomic.LoadInt32(&selectedFrame)
+	if selected == 0 {
+		return liveScope, liveLine
+	}
+	stack := goroutineStack(atomic.LoadUint32(&currentGoroutine))
+	idx := len(stack) - 1 - int(selected)
+	if idx < 0 || idx >= len(stack) || stack[idx].Scope == nil {
+		return liveScope, liveLine
+	}
+	return stack[idx].Scope, stack[idx].Line
+}
+
+// maxInnerFrames and maxOuterFrames mirror the Go runtime's own convention
+// (see runtime.Stack/traceback) of eliding the middle of very deep stacks
+// rather than dumping hundreds of frames.
+const (
+	maxInnerFrames = 10
+	maxOuterFrames = 10
+)
+
+func printBacktrace() {
+	stack := goroutineStack(atomic.LoadUint32(&currentGoroutine))
+	if len(stack) == 0 {
+		activeFrontend.Output("no stack information available")
+		return
+	}
+	var b strings.Builder
+	n := len(stack)
+	for i := n - 1; i >= 0; i-- {
+		frameNum := n - 1 - i
+		if n > maxInnerFrames+maxOuterFrames && frameNum == maxInnerFrames {
+			fmt.Fprintf(&b, "... %d frames elided ...\n", n-maxInnerFrames-maxOuterFrames)
+		}
+		if n > maxInnerFrames+maxOuterFrames && frameNum >= maxInnerFrames && frameNum < n-maxOuterFrames {
+			continue
+		}
+		f := stack[i]
+		marker := "  "
+		if frameNum == int(atomic.LoadInt32(&selectedFrame)) {
+			marker = "* "
+		}
+		fmt.Fprintf(&b, "%s#%d %s %s:%d\n", marker, frameNum, f.FuncName, f.File, f.Line)
+	}
+	activeFrontend.Output(strings.TrimRight(b.String(), "\n"))
+}
+
+func selectFrame(arg string) {
+	n, err := strconv.Atoi(strings.TrimSpace(arg))
+	if err != nil {
+		activeFrontend.Output(fmt.Sprintf("frame: expected a frame number, got %q", arg))
+		return
+	}
+	stack := goroutineStack(atomic.LoadUint32(&currentGoroutine))
+	if n < 0 || n >= len(stack) {
+		activeFrontend.Output(fmt.Sprintf("frame: no such frame %d (have %d frames)", n, len(stack)))
 		return
 	}
-	printLineFromFile(line, file)
+	atomic.StoreInt32(&selectedFrame, int32(n))
+	f := stack[len(stack)-1-n]
+	activeFrontend.Output(fmt.Sprintf("#%d %s %s:%d", n, f.FuncName, f.File, f.Line))
+}
+
+func printGoroutines() {
+	knownGoroutinesMu.Lock()
+	ids := make([]uint32, 0, len(knownGoroutines))
+	for id := range knownGoroutines {
+		ids = append(ids, id)
+	}
+	knownGoroutinesMu.Unlock()
+	var b strings.Builder
+	for _, id := range ids {
+		stack := goroutineStack(id)
+		marker := "  "
+		if id == atomic.LoadUint32(&currentGoroutine) {
+			marker = "* "
+		}
+		if len(stack) == 0 {
+			fmt.Fprintf(&b, "%sgoroutine %d: (no frames)\n", marker, id)
+			continue
+		}
+		top := stack[len(stack)-1]
+		fmt.Fprintf(&b, "%sgoroutine %d: %s %s:%d\n", marker, id, top.FuncName, top.File, top.Line)
+	}
+	activeFrontend.Output(strings.TrimRight(b.String(), "\n"))
+}
+
+func switchGoroutine(arg string) {
+	n, err := strconv.Atoi(strings.TrimSpace(arg))
+	if err != nil {
+		activeFrontend.Output(fmt.Sprintf("goroutine: expected a goroutine id, got %q", arg))
+		return
+	}
+	id := uint32(n)
+	knownGoroutinesMu.Lock()
+	_, known := knownGoroutines[id]
+	knownGoroutinesMu.Unlock()
+	if !known {
+		activeFrontend.Output(fmt.Sprintf("goroutine: no followed goroutine with id %d", id))
+		return
+	}
+	atomic.StoreUint32(&currentGoroutine, id)
+	atomic.StoreInt32(&selectedFrame, 0)
+	activeFrontend.Output(fmt.Sprintf("switched to goroutine %d", id))
+}
+
+func dereference(i interface{}) interface{} {
+	return reflect.ValueOf(i).Elem().Interface()
 }
 
 var parsedFiles map[string][]string
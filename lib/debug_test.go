@@ -0,0 +1,125 @@
+package godebug
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGetVarPostDeclarationVisibility(t *testing.T) {
+	outer := EnteringNewScope()
+	ov := "outer"
+	outer.DeclareParam("shared", &ov)
+
+	inner := outer.EnteringNewChildScope()
+	iv := "inner"
+	inner.DeclareAt(10, "shared", &iv)
+	yv := 2
+	inner.DeclareAt(10, "y", &yv)
+
+	tests := []struct {
+		name        string
+		scope       *Scope
+		varName     string
+		currentLine int
+		wantOK      bool
+		wantValue   interface{}
+	}{
+		{name: "param visible on its own declaration line", scope: outer, varName: "shared", currentLine: 0, wantOK: true, wantValue: &ov},
+		{name: "non-param hidden on its declaration line", scope: inner, varName: "y", currentLine: 10, wantOK: false},
+		{name: "non-param hidden before its declaration line", scope: inner, varName: "y", currentLine: 5, wantOK: false},
+		{name: "non-param visible after its declaration line", scope: inner, varName: "y", currentLine: 11, wantOK: true, wantValue: &yv},
+		{name: "inner shadow hides the outer binding of the same name", scope: inner, varName: "shared", currentLine: 10, wantOK: false},
+		{name: "inner shadow becomes visible once past its declaration", scope: inner, varName: "shared", currentLine: 11, wantOK: true, wantValue: &iv},
+		{name: "unknown name", scope: inner, varName: "nope", currentLine: 100, wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.scope.getVar(tt.varName, tt.currentLine)
+			if ok != tt.wantOK {
+				t.Fatalf("getVar(%q, %d) ok = %v, want %v", tt.varName, tt.currentLine, ok, tt.wantOK)
+			}
+			if ok && got != tt.wantValue {
+				t.Errorf("getVar(%q, %d) = %v, want %v", tt.varName, tt.currentLine, got, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestPrintBacktraceElidesDeepStacks(t *testing.T) {
+	prevFrontend := activeFrontend
+	fe := &captureFrontend{}
+	activeFrontend = fe
+	defer func() { activeFrontend = prevFrontend }()
+
+	const goroutine = uint32(0xdeadbeef)
+	prevGoroutine := atomic.LoadUint32(&currentGoroutine)
+	prevSelected := atomic.LoadInt32(&selectedFrame)
+	atomic.StoreUint32(&currentGoroutine, goroutine)
+	atomic.StoreInt32(&selectedFrame, 0)
+	defer func() {
+		atomic.StoreUint32(&currentGoroutine, prevGoroutine)
+		atomic.StoreInt32(&selectedFrame, prevSelected)
+		framesMu.Lock()
+		delete(frames, goroutine)
+		framesMu.Unlock()
+	}()
+
+	const depth = maxInnerFrames + maxOuterFrames + 5
+	for i := 0; i < depth; i++ {
+		pushFrame(goroutine, "file.go", i, fmt.Sprintf("fn%d", i))
+	}
+
+	printBacktrace()
+
+	if len(fe.texts) != 1 {
+		t.Fatalf("printBacktrace produced %d Output calls, want 1", len(fe.texts))
+	}
+	out := fe.texts[0]
+	wantElided := depth - maxInnerFrames - maxOuterFrames
+	if !strings.Contains(out, fmt.Sprintf("%d frames elided", wantElided)) {
+		t.Errorf("printBacktrace output = %q, want it to mention %d elided frames", out, wantElided)
+	}
+	if got := strings.Count(out, "#"); got != maxInnerFrames+maxOuterFrames {
+		t.Errorf("printBacktrace printed %d frame lines, want %d (the rest should be elided)", got, maxInnerFrames+maxOuterFrames)
+	}
+}
+
+func TestPrintBacktraceNoElisionUnderLimit(t *testing.T) {
+	prevFrontend := activeFrontend
+	fe := &captureFrontend{}
+	activeFrontend = fe
+	defer func() { activeFrontend = prevFrontend }()
+
+	const goroutine = uint32(0xfeedface)
+	prevGoroutine := atomic.LoadUint32(&currentGoroutine)
+	prevSelected := atomic.LoadInt32(&selectedFrame)
+	atomic.StoreUint32(&currentGoroutine, goroutine)
+	atomic.StoreInt32(&selectedFrame, 0)
+	defer func() {
+		atomic.StoreUint32(&currentGoroutine, prevGoroutine)
+		atomic.StoreInt32(&selectedFrame, prevSelected)
+		framesMu.Lock()
+		delete(frames, goroutine)
+		framesMu.Unlock()
+	}()
+
+	const depth = 3
+	for i := 0; i < depth; i++ {
+		pushFrame(goroutine, "file.go", i, fmt.Sprintf("fn%d", i))
+	}
+
+	printBacktrace()
+
+	if len(fe.texts) != 1 {
+		t.Fatalf("printBacktrace produced %d Output calls, want 1", len(fe.texts))
+	}
+	out := fe.texts[0]
+	if strings.Contains(out, "elided") {
+		t.Errorf("printBacktrace output = %q, should not elide a %d-frame stack", out, depth)
+	}
+	if got := strings.Count(out, "#"); got != depth {
+		t.Errorf("printBacktrace printed %d frame lines, want %d", got, depth)
+	}
+}
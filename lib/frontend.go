@@ -0,0 +1,285 @@
+package godebug
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"sync/atomic"
+)
+
+// Command is a single REPL command, already split into a name and its
+// whitespace-separated arguments by the Frontend that parsed it. Raw holds
+// the original, unparsed input for commands (like "break FILE:LINE if EXPR")
+// whose arguments aren't simple whitespace-separated tokens.
+type Command struct {
+	Name string
+	Args []string
+	Raw  string
+}
+
+// Frontend decouples waitForInput's command loop from how commands are
+// read and how output is reported, so the debugger can be driven by
+// something other than a human typing into the stdin REPL.
+type Frontend interface {
+	// Stopped reports that the followed goroutine has paused at file:line
+	// for the given reason ("step", "next", "breakpoint", ...). scope can
+	// be used to look up variables visible at the stop.
+	Stopped(file string, line int, reason string, scope *Scope)
+	// Output reports free-form text, such as the result of a print or
+	// backtrace command.
+	Output(text string)
+	// NextCommand blocks until a command is available and returns it.
+	NextCommand() Command
+}
+
+// valueFrontend is an optional extension to Frontend: frontends that can
+// report values as structured data (rather than pre-formatted text) should
+// implement it. waitForInput prefers it over Output when available.
+type valueFrontend interface {
+	Value(name string, v interface{})
+}
+
+var activeFrontend Frontend
+
+func init() {
+	switch os.Getenv("GODEBUG_FRONTEND") {
+	case "json":
+		SetFrontend(NewJSONFrontend(os.Stdout, os.Stdin))
+	default:
+		SetFrontend(NewTTYFrontend(os.Stdin))
+	}
+}
+
+// SetFrontend installs f as the frontend the debugger talks to, overriding
+// whatever GODEBUG_FRONTEND selected. Embedders that want to drive godebug
+// programmatically (rather than via stdin/stdout) should call this before
+// tripping SetTrace.
+func SetFrontend(f Frontend) {
+	activeFrontend = f
+}
+
+// TTYFrontend is the original interactive REPL: it prompts on stdout and
+// reads commands, one per line, from a LineReader (normally a Readline
+// wired up to os.Stdin, with history and completion).
+type TTYFrontend struct {
+	reader LineReader
+}
+
+// NewTTYFrontend returns a Frontend backed by a Readline reading from r and
+// writing prompts to stdout, with history and completion over built-in
+// commands, in-scope variable names, and (for break) file paths.
+func NewTTYFrontend(r io.Reader) *TTYFrontend {
+	rl := NewReadline(r, os.Stdout)
+	rl.AddCompleter(commandCompleter)
+	rl.AddCompleter(variableCompleter)
+	rl.AddCompleter(fileCompleter)
+	return &TTYFrontend{reader: rl}
+}
+
+// NewTTYFrontendWithReader returns a TTYFrontend driven by an arbitrary
+// LineReader, such as a ScriptedLineReader, instead of a live terminal.
+func NewTTYFrontendWithReader(r LineReader) *TTYFrontend {
+	return &TTYFrontend{reader: r}
+}
+
+func (t *TTYFrontend) Stopped(file string, line int, reason string, scope *Scope) {
+	printLineFromFile(line, file)
+}
+
+func (t *TTYFrontend) Output(text string) {
+	fmt.Println(text)
+}
+
+func (t *TTYFrontend) NextCommand() Command {
+	line, ok := t.reader.ReadLine("(godebug) ")
+	if !ok {
+		return Command{Name: "quit"}
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 1 && (fields[0] == "p" || fields[0] == "print") {
+		if expr, ok := t.readMultilineExpr(); ok {
+			return Command{Name: fields[0], Args: []string{expr}, Raw: fields[0] + " " + expr}
+		}
+	}
+	if len(fields) == 0 {
+		return Command{Raw: line}
+	}
+	return Command{Name: fields[0], Args: fields[1:], Raw: line}
+}
+
+// readMultilineExpr supports a bare "p"/"print" being followed by a
+// multi-line expression terminated by a blank line, rather than requiring
+// the whole expression on one line.
+func (t *TTYFrontend) readMultilineExpr() (string, bool) {
+	var lines []string
+	for {
+		next, ok := t.reader.ReadLine("...> ")
+		if !ok || strings.TrimSpace(next) == "" {
+			break
+		}
+		lines = append(lines, next)
+	}
+	if len(lines) == 0 {
+		return "", false
+	}
+	return strings.Join(lines, " "), true
+}
+
+// JSONFrontend emits one JSON object per line describing stop/output events,
+// and reads newline-delimited JSON commands of the form
+// {"cmd": "print", "args": ["x"]}. It is selected with GODEBUG_FRONTEND=json
+// (or by passing it to SetFrontend directly) so editor plugins and CI-driven
+// tooling can drive the debugger without scraping the "(godebug)" prompt.
+type JSONFrontend struct {
+	enc      *json.Encoder
+	scanner  *bufio.Scanner
+	maxDepth int
+}
+
+// NewJSONFrontend returns a Frontend that writes events to w and reads
+// commands from r. Struct/slice/map values are walked up to maxDepth levels
+// deep when dumped; maxDepth starts at 5 and can be changed with
+// SetMaxDepth.
+func NewJSONFrontend(w io.Writer, r io.Reader) *JSONFrontend {
+	return &JSONFrontend{
+		enc:      json.NewEncoder(w),
+		scanner:  bufio.NewScanner(r),
+		maxDepth: 5,
+	}
+}
+
+// SetMaxDepth overrides the default depth JSONFrontend walks to when
+// dumping struct/slice/map values. A depth <= 0 is ignored.
+func (j *JSONFrontend) SetMaxDepth(depth int) {
+	if depth > 0 {
+		j.maxDepth = depth
+	}
+}
+
+type jsonFrame struct {
+	Func string `json:"func"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+type jsonEvent struct {
+	Type      string      `json:"type"`
+	File      string      `json:"file,omitempty"`
+	Line      int         `json:"line,omitempty"`
+	Reason    string      `json:"reason,omitempty"`
+	Goroutine uint32      `json:"goroutine,omitempty"`
+	Frames    []jsonFrame `json:"frames,omitempty"`
+	Text      string      `json:"text,omitempty"`
+	Name      string      `json:"name,omitempty"`
+	Value     interface{} `json:"value,omitempty"`
+}
+
+func (j *JSONFrontend) Stopped(file string, line int, reason string, scope *Scope) {
+	goroutine := atomic.LoadUint32(&currentGoroutine)
+	j.enc.Encode(jsonEvent{
+		Type:      "stopped",
+		File:      file,
+		Line:      line,
+		Reason:    reason,
+		Goroutine: goroutine,
+		Frames:    jsonFrames(goroutineStack(goroutine)),
+	})
+}
+
+func jsonFrames(stack []*Frame) []jsonFrame {
+	out := make([]jsonFrame, 0, len(stack))
+	for i := len(stack) - 1; i >= 0; i-- {
+		f := stack[i]
+		out = append(out, jsonFrame{Func: f.FuncName, File: f.File, Line: f.Line})
+	}
+	return out
+}
+
+func (j *JSONFrontend) Output(text string) {
+	j.enc.Encode(jsonEvent{Type: "output", Text: text})
+}
+
+func (j *JSONFrontend) Value(name string, v interface{}) {
+	j.enc.Encode(jsonEvent{Type: "value", Name: name, Value: dumpValue(v, j.maxDepth)})
+}
+
+func (j *JSONFrontend) NextCommand() Command {
+	for j.scanner.Scan() {
+		line := strings.TrimSpace(j.scanner.Text())
+		if line == "" {
+			continue
+		}
+		var parsed struct {
+			Cmd  string   `json:"cmd"`
+			Args []string `json:"args"`
+		}
+		if err := json.Unmarshal([]byte(line), &parsed); err != nil {
+			j.Output(fmt.Sprintf("invalid command %q: %v", line, err))
+			continue
+		}
+		return Command{
+			Name: parsed.Cmd,
+			Args: parsed.Args,
+			Raw:  strings.TrimSpace(parsed.Cmd + " " + strings.Join(parsed.Args, " ")),
+		}
+	}
+	return Command{Name: "quit"}
+}
+
+// dumpValue renders v (typically the result of dereference) as a
+// JSON-marshalable generic structure, walking structs/slices/maps up to
+// maxDepth levels deep. Past that depth, composite values fall back to
+// their fmt.Sprintf("%v", ...) text so the output stays bounded.
+func dumpValue(v interface{}, maxDepth int) interface{} {
+	return dumpReflect(reflect.ValueOf(v), maxDepth)
+}
+
+func dumpReflect(v reflect.Value, depth int) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return dumpReflect(v.Elem(), depth)
+	case reflect.Struct:
+		if depth <= 0 {
+			return fmt.Sprintf("%v", v.Interface())
+		}
+		t := v.Type()
+		m := make(map[string]interface{}, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			if t.Field(i).PkgPath != "" {
+				continue // unexported fields aren't reachable via reflect.Interface()
+			}
+			m[t.Field(i).Name] = dumpReflect(v.Field(i), depth-1)
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		if depth <= 0 {
+			return fmt.Sprintf("%v", v.Interface())
+		}
+		out := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out[i] = dumpReflect(v.Index(i), depth-1)
+		}
+		return out
+	case reflect.Map:
+		if depth <= 0 {
+			return fmt.Sprintf("%v", v.Interface())
+		}
+		m := make(map[string]interface{}, v.Len())
+		for _, k := range v.MapKeys() {
+			m[fmt.Sprintf("%v", k.Interface())] = dumpReflect(v.MapIndex(k), depth-1)
+		}
+		return m
+	default:
+		return v.Interface()
+	}
+}
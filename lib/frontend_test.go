@@ -0,0 +1,75 @@
+package godebug
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// captureFrontend is a minimal Frontend that records Output calls instead of
+// printing or encoding them, so tests can assert on what the REPL would have
+// shown without a real terminal or JSON stream.
+type captureFrontend struct {
+	texts []string
+}
+
+func (c *captureFrontend) Stopped(file string, line int, reason string, scope *Scope) {}
+func (c *captureFrontend) Output(text string)                                         { c.texts = append(c.texts, text) }
+func (c *captureFrontend) NextCommand() Command                                       { return Command{Name: "quit"} }
+
+func TestDumpReflect(t *testing.T) {
+	type inner struct {
+		A int
+		b string // unexported: dumpReflect can't reach it via reflect.Interface()
+	}
+	type outer struct {
+		Inner inner
+		List  []int
+	}
+	val := outer{Inner: inner{A: 1, b: "hidden"}, List: []int{1, 2}}
+	ip := 7
+
+	tests := []struct {
+		name  string
+		value interface{}
+		depth int
+		want  interface{}
+	}{
+		{name: "invalid value", value: nil, depth: 5, want: nil},
+		{name: "scalar passes through unchanged", value: 7, depth: 5, want: 7},
+		{name: "pointer is dereferenced", value: &ip, depth: 5, want: 7},
+		{name: "nil pointer becomes nil", value: (*int)(nil), depth: 5, want: nil},
+		{
+			name:  "struct/slice within depth are walked field by field",
+			value: val,
+			depth: 5,
+			want: map[string]interface{}{
+				"Inner": map[string]interface{}{"A": 1},
+				"List":  []interface{}{1, 2},
+			},
+		},
+		{
+			name:  "depth exhausted at the top falls back to %v",
+			value: val,
+			depth: 0,
+			want:  fmt.Sprintf("%v", val),
+		},
+		{
+			name:  "depth exhausted one level down falls back to %v for each field",
+			value: val,
+			depth: 1,
+			want: map[string]interface{}{
+				"Inner": fmt.Sprintf("%v", inner{A: 1, b: "hidden"}),
+				"List":  fmt.Sprintf("%v", []int{1, 2}),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dumpValue(tt.value, tt.depth)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dumpValue(%v, %d) = %#v, want %#v", tt.value, tt.depth, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,338 @@
+package godebug
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// LineReader supplies one line of REPL input at a time. Readline is the
+// production implementation (a terminal, with history and completion);
+// ScriptedLineReader lets callers drive the REPL from a canned script
+// instead, which is what makes waitForInput testable without a real tty.
+type LineReader interface {
+	// ReadLine prompts and returns the next line of input. ok is false at
+	// end of input.
+	ReadLine(prompt string) (line string, ok bool)
+}
+
+// ScriptedLineReader replays a fixed sequence of lines, ignoring the
+// prompt entirely.
+type ScriptedLineReader struct {
+	Lines []string
+	pos   int
+}
+
+// ReadLine returns the next scripted line, or ok=false once Lines is
+// exhausted.
+func (s *ScriptedLineReader) ReadLine(prompt string) (string, bool) {
+	if s.pos >= len(s.Lines) {
+		return "", false
+	}
+	line := s.Lines[s.pos]
+	s.pos++
+	return line, true
+}
+
+// Completer returns completion candidates for word, the token currently
+// being typed.
+type Completer func(word string) []string
+
+// rawTerminal puts a terminal into character-at-a-time, no-echo mode for
+// the duration of Enable, returning a function that restores it. It has a
+// platform-specific implementation (readline_unix.go, readline_windows.go);
+// newRawTerminal returns nil where raw mode isn't available, such as when
+// input is a pipe rather than a tty.
+type rawTerminal interface {
+	Enable() (restore func(), err error)
+}
+
+// Readline is the default interactive LineReader. When its input is a
+// terminal on Linux it supports arrow-key history recall and tab
+// completion; on anything else (a pipe, a file of scripted commands, a
+// terminal on another platform) it falls back to plain line buffering.
+type Readline struct {
+	in          *bufio.Reader
+	out         io.Writer
+	raw         rawTerminal
+	history     []string
+	historyPath string
+	completers  []Completer
+}
+
+// NewReadline returns a Readline reading from in and writing prompts/echo
+// to out, with history loaded from (and appended to) the godebug history
+// file.
+func NewReadline(in io.Reader, out io.Writer) *Readline {
+	rl := &Readline{
+		in:          bufio.NewReader(in),
+		out:         out,
+		historyPath: historyFilePath(),
+	}
+	rl.history = loadHistory(rl.historyPath)
+	rl.raw = newRawTerminal(in)
+	return rl
+}
+
+// AddCompleter registers a completion source. ReadLine consults every
+// registered completer, in order, when the user presses Tab.
+func (rl *Readline) AddCompleter(c Completer) {
+	rl.completers = append(rl.completers, c)
+}
+
+// ReadLine prompts and returns the next line of input.
+func (rl *Readline) ReadLine(prompt string) (string, bool) {
+	var line string
+	var ok bool
+	if rl.raw != nil {
+		line, ok = rl.readLineRaw(prompt)
+	} else {
+		fmt.Fprint(rl.out, prompt)
+		line, ok = rl.readLineCooked()
+	}
+	if ok && strings.TrimSpace(line) != "" {
+		rl.appendHistory(line)
+	}
+	return line, ok
+}
+
+func (rl *Readline) readLineCooked() (string, bool) {
+	line, err := rl.in.ReadString('\n')
+	line = strings.TrimRight(line, "\r\n")
+	if err != nil && line == "" {
+		return "", false
+	}
+	return line, true
+}
+
+// readLineRaw implements a minimal line editor: printable characters,
+// backspace, left/right cursor movement, up/down history recall, and Tab
+// completion. It's deliberately small rather than a full readline port.
+func (rl *Readline) readLineRaw(prompt string) (string, bool) {
+	restore, err := rl.raw.Enable()
+	if err != nil {
+		fmt.Fprint(rl.out, prompt)
+		return rl.readLineCooked()
+	}
+	defer restore()
+
+	var buf []rune
+	pos := 0
+	histIdx := len(rl.history)
+	fmt.Fprint(rl.out, prompt)
+
+	redraw := func() {
+		fmt.Fprint(rl.out, "\r\x1b[K", prompt, string(buf))
+		if back := len(buf) - pos; back > 0 {
+			fmt.Fprintf(rl.out, "\x1b[%dD", back)
+		}
+	}
+
+	for {
+		b, err := rl.in.ReadByte()
+		if err != nil {
+			if len(buf) == 0 {
+				return "", false
+			}
+			return string(buf), true
+		}
+		switch b {
+		case '\r', '\n':
+			fmt.Fprintln(rl.out)
+			return string(buf), true
+		case 3: // Ctrl-C: abandon the current line
+			fmt.Fprintln(rl.out)
+			return "", true
+		case 4: // Ctrl-D
+			if len(buf) == 0 {
+				fmt.Fprintln(rl.out)
+				return "", false
+			}
+		case 127, 8: // backspace
+			if pos > 0 {
+				buf = append(buf[:pos-1], buf[pos:]...)
+				pos--
+				redraw()
+			}
+		case '\t':
+			rl.complete(&buf, &pos, redraw)
+		case 0x1b: // escape sequence: ESC [ <letter>
+			b2, err := rl.in.ReadByte()
+			if err != nil || b2 != '[' {
+				continue
+			}
+			b3, err := rl.in.ReadByte()
+			if err != nil {
+				continue
+			}
+			switch b3 {
+			case 'A': // up
+				if histIdx > 0 {
+					histIdx--
+					buf = []rune(rl.history[histIdx])
+					pos = len(buf)
+					redraw()
+				}
+			case 'B': // down
+				if histIdx < len(rl.history)-1 {
+					histIdx++
+					buf = []rune(rl.history[histIdx])
+				} else {
+					histIdx = len(rl.history)
+					buf = nil
+				}
+				pos = len(buf)
+				redraw()
+			case 'C': // right
+				if pos < len(buf) {
+					pos++
+					redraw()
+				}
+			case 'D': // left
+				if pos > 0 {
+					pos--
+					redraw()
+				}
+			}
+		default:
+			if b >= 0x20 && b < 0x7f {
+				buf = append(buf[:pos], append([]rune{rune(b)}, buf[pos:]...)...)
+				pos++
+				redraw()
+			}
+		}
+	}
+}
+
+func (rl *Readline) complete(buf *[]rune, pos *int, redraw func()) {
+	word, start := currentWord(*buf, *pos)
+	var candidates []string
+	for _, c := range rl.completers {
+		candidates = append(candidates, c(word)...)
+	}
+	candidates = uniqueSorted(candidates)
+	switch len(candidates) {
+	case 0:
+		return
+	case 1:
+		rest := []rune(candidates[0])[len([]rune(word)):]
+		tail := append([]rune{}, (*buf)[*pos:]...)
+		*buf = append((*buf)[:start+len([]rune(word))], append(rest, tail...)...)
+		*pos = start + len([]rune(word)) + len(rest)
+	default:
+		fmt.Fprintln(rl.out)
+		fmt.Fprintln(rl.out, strings.Join(candidates, "  "))
+	}
+	redraw()
+}
+
+func currentWord(buf []rune, pos int) (word string, start int) {
+	start = pos
+	for start > 0 && buf[start-1] != ' ' && buf[start-1] != '\t' {
+		start--
+	}
+	return string(buf[start:pos]), start
+}
+
+func uniqueSorted(items []string) []string {
+	seen := make(map[string]bool, len(items))
+	out := make([]string, 0, len(items))
+	for _, it := range items {
+		if !seen[it] {
+			seen[it] = true
+			out = append(out, it)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// historyFilePath follows $XDG_STATE_HOME/godebug/history, falling back to
+// ~/.godebug_history when XDG_STATE_HOME isn't set.
+func historyFilePath() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "godebug", "history")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".godebug_history")
+}
+
+func loadHistory(path string) []string {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var history []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			history = append(history, line)
+		}
+	}
+	return history
+}
+
+func (rl *Readline) appendHistory(line string) {
+	rl.history = append(rl.history, line)
+	if rl.historyPath == "" {
+		return
+	}
+	if dir := filepath.Dir(rl.historyPath); dir != "" {
+		os.MkdirAll(dir, 0o700)
+	}
+	f, err := os.OpenFile(rl.historyPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
+
+// commandCompleter completes against the names registered in commandTable.
+func commandCompleter(word string) []string {
+	var out []string
+	for name := range commandTable {
+		if strings.HasPrefix(name, word) {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// variableCompleter completes against the names visible in the currently
+// selected frame's scope chain.
+func variableCompleter(word string) []string {
+	stack := goroutineStack(atomic.LoadUint32(&currentGoroutine))
+	idx := len(stack) - 1 - int(atomic.LoadInt32(&selectedFrame))
+	if idx < 0 || idx >= len(stack) || stack[idx].Scope == nil {
+		return nil
+	}
+	var out []string
+	for sc := stack[idx].Scope; sc != nil; sc = sc.parent {
+		for name := range sc.vars {
+			if _, visible := stack[idx].Scope.getVar(name, stack[idx].Line); !visible {
+				continue
+			}
+			if strings.HasPrefix(name, word) {
+				out = append(out, name)
+			}
+		}
+	}
+	return out
+}
+
+// fileCompleter completes against file paths, for "break FILE:LINE".
+func fileCompleter(word string) []string {
+	matches, _ := filepath.Glob(word + "*")
+	return matches
+}
@@ -0,0 +1,14 @@
+//go:build !windows && !linux
+
+package godebug
+
+import "io"
+
+// newRawTerminal has no raw-mode implementation for this platform (the
+// TCGETS/TCSETS ioctl numbers and termios layout in readline_unix.go are
+// Linux-specific), so Readline always falls back to plain line buffering
+// here: no arrow-key history recall or tab completion mid-line, but correct
+// input either way.
+func newRawTerminal(in io.Reader) rawTerminal {
+	return nil
+}
@@ -0,0 +1,65 @@
+//go:build linux
+
+package godebug
+
+import (
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// tcgets/tcsets and the termios layout below are Linux's ioctl numbers and
+// kernel struct termios ABI specifically -- darwin and the BSDs define
+// different ioctl numbers and a differently-laid-out termios, so this file
+// is Linux-only; see readline_other.go for the rest of unix.
+const (
+	tcgets = 0x5401
+	tcsets = 0x5402
+)
+
+// termios mirrors the Linux kernel's struct termios ABI (not glibc's
+// enlarged one), which is what the TCGETS/TCSETS ioctls expect.
+type termios struct {
+	Iflag, Oflag, Cflag, Lflag uint32
+	Line                       uint8
+	Cc                         [19]uint8
+	Ispeed, Ospeed             uint32
+}
+
+type unixRawTerminal struct {
+	fd uintptr
+}
+
+// newRawTerminal returns a rawTerminal for in if it's backed by a terminal
+// file descriptor, or nil if raw mode isn't available, e.g. because in is a
+// pipe feeding scripted/CI input.
+func newRawTerminal(in io.Reader) rawTerminal {
+	f, ok := in.(*os.File)
+	if !ok {
+		return nil
+	}
+	var t termios
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), tcgets, uintptr(unsafe.Pointer(&t))); errno != 0 {
+		return nil
+	}
+	return &unixRawTerminal{fd: f.Fd()}
+}
+
+func (u *unixRawTerminal) Enable() (func(), error) {
+	var orig termios
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, u.fd, tcgets, uintptr(unsafe.Pointer(&orig))); errno != 0 {
+		return nil, errno
+	}
+	raw := orig
+	raw.Lflag &^= uint32(syscall.ICANON | syscall.ECHO)
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, u.fd, tcsets, uintptr(unsafe.Pointer(&raw))); errno != 0 {
+		return nil, errno
+	}
+	restore := func() {
+		syscall.Syscall(syscall.SYS_IOCTL, u.fd, tcsets, uintptr(unsafe.Pointer(&orig)))
+	}
+	return restore, nil
+}
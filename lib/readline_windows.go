@@ -0,0 +1,11 @@
+//go:build windows
+
+package godebug
+
+import "io"
+
+// newRawTerminal has no Windows console implementation yet, so Readline
+// always falls back to plain line buffering there.
+func newRawTerminal(in io.Reader) rawTerminal {
+	return nil
+}
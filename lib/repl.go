@@ -0,0 +1,174 @@
+package godebug
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// commandHandler runs a parsed REPL command. scope and currentLine are the
+// live scope/line waitForInput was called with (before any "frame N"
+// selection is applied). It returns true if waitForInput should stop
+// waiting and let execution resume (e.g. "next"/"step"/"continue").
+type commandHandler func(cmd Command, scope *Scope, currentLine int) (resume bool)
+
+// commandTable maps command names to their handlers. It exists so that
+// each request that adds a REPL command can register it independently,
+// rather than everyone editing one central switch statement.
+var commandTable = map[string]commandHandler{}
+
+// registerCommand registers h under every name in names.
+func registerCommand(names []string, h commandHandler) {
+	for _, name := range names {
+		commandTable[name] = h
+	}
+}
+
+func init() {
+	registerCommand([]string{"n", "next"}, func(cmd Command, scope *Scope, currentLine int) bool {
+		atomic.StoreInt32(&currentState, next)
+		return true
+	})
+	registerCommand([]string{"s", "step"}, func(cmd Command, scope *Scope, currentLine int) bool {
+		atomic.StoreInt32(&currentState, step)
+		return true
+	})
+	registerCommand([]string{"c", "continue"}, func(cmd Command, scope *Scope, currentLine int) bool {
+		atomic.StoreInt32(&currentState, run)
+		return true
+	})
+	registerCommand([]string{"bt", "backtrace"}, func(cmd Command, scope *Scope, currentLine int) bool {
+		printBacktrace()
+		return false
+	})
+	registerCommand([]string{"goroutines"}, func(cmd Command, scope *Scope, currentLine int) bool {
+		printGoroutines()
+		return false
+	})
+	registerCommand([]string{"frame"}, func(cmd Command, scope *Scope, currentLine int) bool {
+		if len(cmd.Args) != 1 {
+			activeFrontend.Output("frame: expected exactly one argument, a frame number")
+			return false
+		}
+		selectFrame(cmd.Args[0])
+		return false
+	})
+	registerCommand([]string{"goroutine"}, func(cmd Command, scope *Scope, currentLine int) bool {
+		if len(cmd.Args) != 1 {
+			activeFrontend.Output("goroutine: expected exactly one argument, a goroutine id")
+			return false
+		}
+		switchGoroutine(cmd.Args[0])
+		return false
+	})
+	registerCommand([]string{"break", "set-breakpoint"}, func(cmd Command, scope *Scope, currentLine int) bool {
+		cmdBreak(strings.Join(cmd.Args, " "))
+		return false
+	})
+	registerCommand([]string{"breakpoints"}, func(cmd Command, scope *Scope, currentLine int) bool {
+		cmdListBreakpoints()
+		return false
+	})
+	registerCommand([]string{"clear"}, func(cmd Command, scope *Scope, currentLine int) bool {
+		if len(cmd.Args) != 1 {
+			activeFrontend.Output("clear: expected exactly one argument, a breakpoint number")
+			return false
+		}
+		cmdClear(cmd.Args[0])
+		return false
+	})
+	registerCommand([]string{"condition"}, func(cmd Command, scope *Scope, currentLine int) bool {
+		if len(cmd.Args) < 2 {
+			activeFrontend.Output(`condition: expected a breakpoint number and an expression, e.g. "condition 1 x == 5"`)
+			return false
+		}
+		cmdCondition(cmd.Args[0], strings.Join(cmd.Args[1:], " "))
+		return false
+	})
+	registerCommand([]string{"p", "print"}, func(cmd Command, scope *Scope, currentLine int) bool {
+		if len(cmd.Args) != 1 {
+			activeFrontend.Output("print: expected exactly one argument, a variable name")
+			return false
+		}
+		if !printVar(scope, currentLine, cmd.Args[0]) {
+			activeFrontend.Output(fmt.Sprintf("Command not recognized, sorry! You typed: %q", cmd.Raw))
+		}
+		return false
+	})
+	registerCommand([]string{"list", "l"}, func(cmd Command, scope *Scope, currentLine int) bool {
+		printSourceContext(currentLine)
+		return false
+	})
+	registerCommand([]string{"locals"}, func(cmd Command, scope *Scope, currentLine int) bool {
+		printLocals(scope, currentLine)
+		return false
+	})
+}
+
+// sourceContextLines is how many lines of source "list"/"l" shows on either
+// side of the current line.
+const sourceContextLines = 5
+
+func printSourceContext(currentLine int) {
+	stack := goroutineStack(atomic.LoadUint32(&currentGoroutine))
+	idx := len(stack) - 1 - int(atomic.LoadInt32(&selectedFrame))
+	if idx < 0 || idx >= len(stack) {
+		activeFrontend.Output("list: no source location available")
+		return
+	}
+	f := stack[idx]
+	lines, ok := parsedFiles[f.File]
+	if !ok {
+		lines = parseFile(f.File)
+		parsedFiles[f.File] = lines
+	}
+	start := f.Line - sourceContextLines
+	if start < 0 {
+		start = 0
+	}
+	end := f.Line + sourceContextLines
+	if end >= len(lines) {
+		end = len(lines) - 1
+	}
+	var b strings.Builder
+	for i := start; i <= end; i++ {
+		marker := "   "
+		if i == f.Line {
+			marker = "-> "
+		}
+		fmt.Fprintf(&b, "%s%4d %s\n", marker, i, lines[i])
+	}
+	activeFrontend.Output(strings.TrimRight(b.String(), "\n"))
+}
+
+// printLocals dumps every name reachable through the scope chain that
+// frame/line currently resolve to, sorted by name. A name declared in
+// several scopes is shown once, with its innermost binding.
+func printLocals(scope *Scope, currentLine int) {
+	activeScope, activeLine := frameScope(scope, currentLine)
+	seen := make(map[string]bool)
+	var names []string
+	for sc := activeScope; sc != nil; sc = sc.parent {
+		for name := range sc.vars {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		v, ok := activeScope.getVar(name, activeLine)
+		if !ok {
+			continue // declared here, but not yet visible at this line
+		}
+		fmt.Fprintf(&b, "%s = %v\n", name, dereference(v))
+	}
+	if b.Len() == 0 {
+		activeFrontend.Output("no locals visible here")
+		return
+	}
+	activeFrontend.Output(strings.TrimRight(b.String(), "\n"))
+}
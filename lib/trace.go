@@ -0,0 +1,210 @@
+package godebug
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// tracingEnabled is the single flag Line/EnterFunc/ExitFunc check before
+// doing any tracing work, so that the instrumented program pays nothing
+// more than one atomic load when no trace is running.
+var tracingEnabled int32
+
+// traceEvent is one entry in the Chrome Trace Event Format stream
+// (https://chromium.googlesource.com/catapult), readable by
+// chrome://tracing and any other tool built on that format. `go tool
+// trace` itself only reads the runtime's own binary trace format, which
+// this package doesn't attempt to emit -- the request allowed this JSON
+// format as a fallback, and it needs no matching parser shipped with this
+// package.
+type traceEvent struct {
+	Name string                 `json:"name,omitempty"`
+	Cat  string                 `json:"cat"`
+	Ph   string                 `json:"ph"`
+	Ts   float64                `json:"ts"`
+	Pid  int                    `json:"pid"`
+	Tid  uint32                 `json:"tid"`
+	Args map[string]interface{} `json:"args,omitempty"`
+}
+
+// traceRingSize is how many events a goroutine buffers before it forces a
+// flush, bounding memory use without a lock held across writer I/O.
+const traceRingSize = 1024
+
+// goroutineRing buffers one goroutine's events so that emitting a trace
+// event never contends with other goroutines' tracing.
+type goroutineRing struct {
+	mu     sync.Mutex
+	events []traceEvent
+}
+
+func (r *goroutineRing) add(ev traceEvent) {
+	r.mu.Lock()
+	r.events = append(r.events, ev)
+	overflow := len(r.events) >= traceRingSize
+	var flushed []traceEvent
+	if overflow {
+		flushed = r.events
+		r.events = make([]traceEvent, 0, traceRingSize)
+	}
+	r.mu.Unlock()
+	if overflow {
+		writeTraceEvents(flushed)
+	}
+}
+
+func (r *goroutineRing) drain() {
+	r.mu.Lock()
+	flushed := r.events
+	r.events = nil
+	r.mu.Unlock()
+	writeTraceEvents(flushed)
+}
+
+var (
+	tracesMu   sync.Mutex
+	traceStart time.Time
+	traceOut   *bufio.Writer
+	traceEnc   *json.Encoder
+	traceRings sync.Map // uint32 goroutine id -> *goroutineRing
+)
+
+func ringFor(goroutine uint32) *goroutineRing {
+	v, _ := traceRings.LoadOrStore(goroutine, &goroutineRing{})
+	return v.(*goroutineRing)
+}
+
+func writeTraceEvents(events []traceEvent) {
+	if len(events) == 0 {
+		return
+	}
+	tracesMu.Lock()
+	defer tracesMu.Unlock()
+	if traceEnc == nil {
+		return
+	}
+	for _, ev := range events {
+		traceEnc.Encode(ev)
+	}
+}
+
+// StartTrace begins recording a Chrome Trace Event Format stream (readable
+// by chrome://tracing, not by `go tool trace`, which only reads the
+// runtime's own binary format) of function regions and paused lines,
+// across every goroutine godebug is instrumenting, to w. It follows the
+// shape of runtime/trace.Start: call StopTrace to stop recording and flush
+// everything buffered to w.
+func StartTrace(w io.Writer) error {
+	tracesMu.Lock()
+	defer tracesMu.Unlock()
+	if traceEnc != nil {
+		return fmt.Errorf("godebug: trace already started")
+	}
+	traceOut = bufio.NewWriter(w)
+	traceEnc = json.NewEncoder(traceOut)
+	traceStart = time.Now()
+	atomic.StoreInt32(&tracingEnabled, 1)
+	return nil
+}
+
+// StopTrace stops the trace started by StartTrace, flushing any buffered
+// events to the writer it was given.
+func StopTrace() {
+	atomic.StoreInt32(&tracingEnabled, 0)
+	traceRings.Range(func(_, v interface{}) bool {
+		v.(*goroutineRing).drain()
+		return true
+	})
+	tracesMu.Lock()
+	defer tracesMu.Unlock()
+	if traceOut != nil {
+		traceOut.Flush()
+	}
+	traceEnc = nil
+	traceOut = nil
+}
+
+func traceTs() float64 {
+	return float64(time.Since(traceStart).Microseconds())
+}
+
+// traceGoStart records that goroutine has started running instrumented
+// code, giving it a name in the trace viewer's thread list. It's paired
+// with traceGoEnd as a duration event (B/E, like traceRegionBegin/End)
+// rather than a nestable async event, since those require an id to pair
+// begin and end and a goroutine's lifetime is already properly nested
+// around every region it runs.
+func traceGoStart(goroutine uint32) {
+	if atomic.LoadInt32(&tracingEnabled) == 0 {
+		return
+	}
+	ringFor(goroutine).add(traceEvent{
+		Name: fmt.Sprintf("goroutine %d", goroutine),
+		Cat:  "goroutine",
+		Ph:   "B",
+		Ts:   traceTs(),
+		Pid:  1,
+		Tid:  goroutine,
+	})
+}
+
+// traceGoEnd closes the duration event opened by the matching traceGoStart.
+func traceGoEnd(goroutine uint32) {
+	if atomic.LoadInt32(&tracingEnabled) == 0 {
+		return
+	}
+	ringFor(goroutine).add(traceEvent{Cat: "goroutine", Ph: "E", Ts: traceTs(), Pid: 1, Tid: goroutine})
+}
+
+// traceRegionBegin records entry into funcName as a user region, rendered
+// as a nested duration bar by chrome://tracing and compatible viewers.
+func traceRegionBegin(goroutine uint32, funcName string) {
+	if atomic.LoadInt32(&tracingEnabled) == 0 {
+		return
+	}
+	ringFor(goroutine).add(traceEvent{Name: funcName, Cat: "region", Ph: "B", Ts: traceTs(), Pid: 1, Tid: goroutine})
+}
+
+// traceRegionEnd closes the region opened by the matching traceRegionBegin.
+func traceRegionEnd(goroutine uint32) {
+	if atomic.LoadInt32(&tracingEnabled) == 0 {
+		return
+	}
+	ringFor(goroutine).add(traceEvent{Cat: "region", Ph: "E", Ts: traceTs(), Pid: 1, Tid: goroutine})
+}
+
+// traceUserLog records a line the debugger paused on, including its source
+// text, as an instant event.
+func traceUserLog(goroutine uint32, file string, line int, text string) {
+	if atomic.LoadInt32(&tracingEnabled) == 0 {
+		return
+	}
+	ringFor(goroutine).add(traceEvent{
+		Name: "line",
+		Cat:  "log",
+		Ph:   "i",
+		Ts:   traceTs(),
+		Pid:  1,
+		Tid:  goroutine,
+		Args: map[string]interface{}{"file": file, "line": line, "text": text},
+	})
+}
+
+// sourceLineText returns the text of line in file, using the same parsed-
+// file cache as the REPL's "list" output.
+func sourceLineText(file string, line int) string {
+	lines, ok := parsedFiles[file]
+	if !ok {
+		lines = parseFile(file)
+		parsedFiles[file] = lines
+	}
+	if line < 0 || line >= len(lines) {
+		return ""
+	}
+	return lines[line]
+}